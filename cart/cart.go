@@ -0,0 +1,153 @@
+package cart
+
+import (
+	"fmt"
+	"lugbit/projects/checkout/database"
+	"lugbit/projects/checkout/user"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CartItem is a single line in a user's cart.
+type CartItem struct {
+	SKU string `json:"sku"`
+	Qty int    `json:"qty"`
+}
+
+// CartItemView is a cart line enriched with pricing, as returned by ListCart.
+type CartItemView struct {
+	SKU      string  `json:"sku"`
+	Qty      int     `json:"qty"`
+	Price    float64 `json:"price"`
+	Subtotal float64 `json:"subtotal"`
+}
+
+// UpsertCartItemRequest adds a new cart line or updates the quantity of an existing one.
+type UpsertCartItemRequest struct {
+	SKU string `json:"sku"`
+	Qty int    `json:"qty"`
+}
+
+// ListCartResponse is the cart contents for a user along with a grand total.
+type ListCartResponse struct {
+	UserID     string         `json:"user_id"`
+	Items      []CartItemView `json:"items"`
+	GrandTotal float64        `json:"grand_total"`
+}
+
+// UpsertCartItem adds an item to the cart, or updates its quantity if the sku is already
+// present for the user. Keyed on user_id + sku, where user_id is the authenticated caller.
+func UpsertCartItem(ctx *gin.Context) {
+	userID, ok := user.UserIDFromContext(ctx)
+	if !ok || userID == "" {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	var req UpsertCartItemRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid JSON body"})
+		return
+	}
+
+	if req.SKU == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "sku required"})
+		return
+	}
+
+	if req.Qty <= 0 {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "qty must be positive"})
+		return
+	}
+
+	query := `
+		INSERT INTO cart (user_id, sku, qty, added_at)
+		VALUES ($1, $2, $3, now())
+		ON CONFLICT (user_id, sku) DO UPDATE SET qty = $3`
+
+	stmt, err := database.Db.Prepare(query)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "error with preparing SQL"})
+		return
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(userID, req.SKU, req.Qty); err != nil {
+		fmt.Println(err)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "unable to upsert cart item"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"user_id": userID, "sku": req.SKU, "qty": req.Qty})
+}
+
+// RemoveCartItem removes a single sku from the authenticated caller's cart.
+func RemoveCartItem(ctx *gin.Context) {
+	userID, ok := user.UserIDFromContext(ctx)
+	if !ok || userID == "" {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	sku := ctx.Param("sku")
+	if sku == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "sku required"})
+		return
+	}
+
+	result, err := database.Db.Exec("DELETE FROM cart WHERE user_id = $1 AND sku = $2", userID, sku)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "unable to remove cart item"})
+		return
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("sku %s not in cart", sku)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"user_id": userID, "sku": sku, "removed": true})
+}
+
+// ListCart returns the items currently in the authenticated caller's cart, each with a per-line
+// subtotal, and a grand total across the whole cart.
+func ListCart(ctx *gin.Context) {
+	userID, ok := user.UserIDFromContext(ctx)
+	if !ok || userID == "" {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	query := `
+		SELECT cart.sku, cart.qty, product.price
+		FROM cart
+		JOIN product ON product.sku = cart.sku
+		WHERE cart.user_id = $1`
+
+	rows, err := database.Db.Query(query, userID)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "error fetching cart"})
+		return
+	}
+	defer rows.Close()
+
+	resp := ListCartResponse{UserID: userID, Items: []CartItemView{}}
+	for rows.Next() {
+		var item CartItemView
+		if err := rows.Scan(&item.SKU, &item.Qty, &item.Price); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "error scanning into struct: " + err.Error()})
+			return
+		}
+		item.Subtotal = item.Price * float64(item.Qty)
+		resp.GrandTotal += item.Subtotal
+		resp.Items = append(resp.Items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "error processing rows"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,240 @@
+package cart
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"lugbit/projects/checkout/database"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+// withUserID simulates the user.RequireAuth middleware injecting the authenticated user id.
+func withUserID(userID string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Set("user_id", userID)
+		ctx.Next()
+	}
+}
+
+func TestUpsertCartItem(t *testing.T) {
+	var userID = "999"
+	tests := []struct {
+		name             string
+		requestBody      string
+		mockSetup        func(mock sqlmock.Sqlmock)
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			name:             "missing sku",
+			requestBody:      `{"qty":1}`,
+			mockSetup:        func(mock sqlmock.Sqlmock) {},
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: `{"error":"sku required"}`,
+		},
+		{
+			name:             "non-positive qty",
+			requestBody:      `{"sku":"120P90","qty":0}`,
+			mockSetup:        func(mock sqlmock.Sqlmock) {},
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: `{"error":"qty must be positive"}`,
+		},
+		{
+			name:        "upsert on conflict updates quantity",
+			requestBody: `{"sku":"120P90","qty":3}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPrepare("INSERT INTO cart \\(user_id, sku, qty, added_at\\) VALUES \\(\\$1, \\$2, \\$3, now\\(\\)\\) ON CONFLICT \\(user_id, sku\\) DO UPDATE SET qty = \\$3").
+					ExpectExec().
+					WithArgs(userID, "120P90", 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"qty":3,"sku":"120P90","user_id":"999"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock database: %s", err)
+			}
+			defer db.Close()
+			database.Db = db
+
+			tc.mockSetup(mock)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+			router.POST("/cart", withUserID(userID), UpsertCartItem)
+
+			req, err := http.NewRequest(http.MethodPost, "/cart", bytes.NewBufferString(tc.requestBody))
+			if err != nil {
+				t.Fatalf("failed to create HTTP request: %s", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+
+			if body := rr.Body.String(); body != tc.expectedResponse {
+				t.Errorf("expected response %s, got %s", tc.expectedResponse, body)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRemoveCartItem(t *testing.T) {
+	var userID = "999"
+	tests := []struct {
+		name             string
+		sku              string
+		mockSetup        func(mock sqlmock.Sqlmock)
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			name: "removes existing item",
+			sku:  "120P90",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM cart WHERE user_id = \\$1 AND sku = \\$2").
+					WithArgs(userID, "120P90").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"removed":true,"sku":"120P90","user_id":"999"}`,
+		},
+		{
+			name: "sku not in cart",
+			sku:  "UNKNOWN",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM cart WHERE user_id = \\$1 AND sku = \\$2").
+					WithArgs(userID, "UNKNOWN").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedStatus:   http.StatusNotFound,
+			expectedResponse: `{"error":"sku UNKNOWN not in cart"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock database: %s", err)
+			}
+			defer db.Close()
+			database.Db = db
+
+			tc.mockSetup(mock)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+			router.DELETE("/cart/:sku", withUserID(userID), RemoveCartItem)
+
+			req, err := http.NewRequest(http.MethodDelete, "/cart/"+tc.sku, nil)
+			if err != nil {
+				t.Fatalf("failed to create HTTP request: %s", err)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+
+			if body := rr.Body.String(); body != tc.expectedResponse {
+				t.Errorf("expected response %s, got %s", tc.expectedResponse, body)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestListCart(t *testing.T) {
+	var userID = "999"
+	tests := []struct {
+		name             string
+		mockSetup        func(mock sqlmock.Sqlmock)
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			name: "returns items with subtotals and grand total",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"sku", "qty", "price"}).
+					AddRow("120P90", 2, 10.0).
+					AddRow("43N23P", 1, 20.0)
+				mock.ExpectQuery("SELECT cart.sku, cart.qty, product.price FROM cart JOIN product ON product.sku = cart.sku WHERE cart.user_id = \\$1").
+					WithArgs(userID).
+					WillReturnRows(rows)
+			},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"user_id":"999","items":[{"sku":"120P90","qty":2,"price":10,"subtotal":20},{"sku":"43N23P","qty":1,"price":20,"subtotal":20}],"grand_total":40}`,
+		},
+		{
+			name: "empty cart",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"sku", "qty", "price"})
+				mock.ExpectQuery("SELECT cart.sku, cart.qty, product.price FROM cart JOIN product ON product.sku = cart.sku WHERE cart.user_id = \\$1").
+					WithArgs(userID).
+					WillReturnRows(rows)
+			},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"user_id":"999","items":[],"grand_total":0}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock database: %s", err)
+			}
+			defer db.Close()
+			database.Db = db
+
+			tc.mockSetup(mock)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+			router.GET("/cart", withUserID(userID), ListCart)
+
+			req, err := http.NewRequest(http.MethodGet, "/cart", nil)
+			if err != nil {
+				t.Fatalf("failed to create HTTP request: %s", err)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+
+			if body := rr.Body.String(); body != tc.expectedResponse {
+				t.Errorf("expected response %s, got %s", tc.expectedResponse, body)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
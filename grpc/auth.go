@@ -0,0 +1,61 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"lugbit/projects/checkout/user"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// contextKey is an unexported type for context values set by this package, to avoid colliding
+// with keys set by other packages.
+type contextKey string
+
+// contextUserIDKey is the context key UnaryAuthInterceptor injects the resolved user id under.
+const contextUserIDKey contextKey = "user_id"
+
+// authRequiredMethods are the full gRPC method names that require a valid bearer token,
+// mirroring which HTTP routes in main.go are wrapped in user.RequireAuth().
+var authRequiredMethods = map[string]bool{
+	"/checkout.CheckoutService/Purchase": true,
+}
+
+// UnaryAuthInterceptor validates the bearer token carried in the "authorization" gRPC metadata
+// and injects the resolved user id into the request context, the gRPC equivalent of
+// user.RequireAuth for the HTTP transport. Methods not listed in authRequiredMethods are passed
+// through unauthenticated. Requests to a gated method without a valid, unexpired token are
+// rejected with Unauthenticated.
+func UnaryAuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !authRequiredMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing authorization metadata")
+	}
+
+	const prefix = "Bearer "
+	values := md.Get("authorization")
+	if len(values) == 0 || !strings.HasPrefix(values[0], prefix) {
+		return nil, status.Error(codes.Unauthenticated, "missing or invalid authorization metadata")
+	}
+
+	userID, err := user.ValidateToken(strings.TrimPrefix(values[0], prefix))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+	}
+
+	return handler(context.WithValue(ctx, contextUserIDKey, userID), req)
+}
+
+// userIDFromContext returns the authenticated user id injected by UnaryAuthInterceptor.
+func userIDFromContext(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(contextUserIDKey).(string)
+	return v, ok
+}
@@ -0,0 +1,108 @@
+// Package grpc wires the ProductService/CheckoutService gRPC contracts defined in
+// grpc/proto/checkout.proto to the same service layer used by the HTTP handlers in the
+// product package, so both transports return identical semantics and errors.
+package grpc
+
+import (
+	"context"
+
+	"lugbit/projects/checkout/grpc/pb"
+	"lugbit/projects/checkout/product"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements pb.ProductServiceServer and pb.CheckoutServiceServer.
+type Server struct {
+	pb.UnimplementedProductServiceServer
+	pb.UnimplementedCheckoutServiceServer
+}
+
+// NewServer returns a gRPC server implementation backed by the product service layer.
+func NewServer() *Server {
+	return &Server{}
+}
+
+// ListProducts lists all available products in the database.
+func (s *Server) ListProducts(ctx context.Context, req *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
+	products, err := product.ListProductsService()
+	if err != nil {
+		return nil, status.Error(codes.Internal, "error with fetching products")
+	}
+
+	resp := &pb.ListProductsResponse{}
+	for _, p := range products {
+		resp.Products = append(resp.Products, &pb.Product{
+			Sku:   p.SKU,
+			Name:  p.Name,
+			Price: p.Price,
+			Qty:   p.Qty,
+		})
+	}
+
+	return resp, nil
+}
+
+// AddProduct adds a new product to the database. If the SKU already exists, insert will fail.
+func (s *Server) AddProduct(ctx context.Context, req *pb.AddProductRequest) (*pb.AddProductResponse, error) {
+	p := req.GetProduct()
+
+	err := product.AddProductService(product.Product{
+		SKU:   p.GetSku(),
+		Name:  p.GetName(),
+		Price: p.GetPrice(),
+		Qty:   p.GetQty(),
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to add new product")
+	}
+
+	return &pb.AddProductResponse{}, nil
+}
+
+// Purchase "purchases" the caller's current cart contents from the inventory, mapping domain
+// errors from the service layer onto the equivalent gRPC status codes. The user id comes from
+// the bearer token validated by UnaryAuthInterceptor, not from the request message.
+func (s *Server) Purchase(ctx context.Context, req *pb.PurchaseRequest) (*pb.PurchaseResponse, error) {
+	userID, ok := userIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	result, err := product.PurchaseService(userID)
+	if err != nil {
+		return nil, purchaseStatus(err)
+	}
+
+	resp := &pb.PurchaseResponse{
+		UserId:     result.UserID,
+		TotalPrice: result.TotalPrice,
+		OrderId:    result.OrderID,
+	}
+	for _, item := range result.ItemsPurchased {
+		resp.ItemsPurchased = append(resp.ItemsPurchased, &pb.PurchaseItem{
+			Sku: item.SKU,
+			Qty: int32(item.Qty),
+		})
+	}
+
+	return resp, nil
+}
+
+// purchaseStatus maps a PurchaseService error to the gRPC status it should be reported as.
+func purchaseStatus(err error) error {
+	switch err.(type) {
+	case *product.ErrNotFoundSKU:
+		return status.Error(codes.NotFound, err.Error())
+	case *product.ErrInsufficientStock:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	switch err {
+	case product.ErrUserIDRequired, product.ErrCartEmpty:
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return status.Error(codes.Internal, "could not complete purchase")
+}
@@ -0,0 +1,144 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"lugbit/projects/checkout/database"
+	"lugbit/projects/checkout/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ttl is how long a stored idempotency record remains valid before its key can be reused for a
+// different request.
+const ttl = 24 * time.Hour
+
+// Middleware honors the Idempotency-Key header on write endpoints. A first request with a given
+// key claims it and executes normally, with its response cached once the handler completes —
+// whether it succeeds or the handler aborts with an error — so a retry with the same key always
+// replays the original outcome instead of re-executing; a replay with the same key and an
+// identical request body returns the cached response without re-executing; a replay with the
+// same key but a different body is rejected with 422. Requests without the header are passed
+// through unchanged.
+//
+// The key is claimed with an INSERT ... ON CONFLICT DO NOTHING before the handler runs, so two
+// concurrent requests with the same key can't both see "no existing row" and both execute: only
+// one claims it, and the other is rejected or served the cached response.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader("Idempotency-Key")
+		if key == "" {
+			ctx.Next()
+			return
+		}
+
+		body, err := ctx.GetRawData()
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		requestHash := hashBody(body)
+		userID, _ := user.UserIDFromContext(ctx)
+
+		result, err := database.Db.Exec(
+			`INSERT INTO idempotency_keys (key, user_id, request_hash, response_body, status_code, created_at, expires_at)
+			 VALUES ($1, $2, $3, '', 0, now(), now() + $4)
+			 ON CONFLICT (key, user_id) DO NOTHING`,
+			key, userID, requestHash, ttl,
+		)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "could not check idempotency key"})
+			return
+		}
+
+		claimed, err := result.RowsAffected()
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "could not check idempotency key"})
+			return
+		}
+
+		if claimed == 0 {
+			// someone else already holds this key: either their request has finished (replay)
+			// or is still executing (concurrent duplicate).
+			var storedHash, storedBody string
+			var storedStatus int
+			row := database.Db.QueryRow(
+				`SELECT request_hash, response_body, status_code FROM idempotency_keys
+				 WHERE key = $1 AND user_id = $2 AND expires_at > now()`,
+				key, userID,
+			)
+			if err := row.Scan(&storedHash, &storedBody, &storedStatus); err != nil {
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "could not check idempotency key"})
+				return
+			}
+
+			if storedHash != requestHash {
+				ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+					"error": "idempotency key already used with a different request",
+				})
+				return
+			}
+
+			if storedStatus == 0 {
+				ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{
+					"error": "a request with this idempotency key is already in progress",
+				})
+				return
+			}
+
+			ctx.Data(storedStatus, "application/json", []byte(storedBody))
+			ctx.Abort()
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: ctx.Writer, status: http.StatusOK}
+		ctx.Writer = rec
+
+		ctx.Next()
+
+		// persist whatever the handler produced, including an aborted error response: the
+		// request has still been resolved, so a retry with the same key must replay that
+		// outcome rather than finding status_code still 0 and being told it's "in progress"
+		// forever.
+		_, err = database.Db.Exec(
+			`UPDATE idempotency_keys SET response_body = $1, status_code = $2 WHERE key = $3 AND user_id = $4`,
+			rec.body.String(), rec.status, key, userID,
+		)
+		if err != nil {
+			fmt.Println(err)
+		}
+	}
+}
+
+// hashBody returns a hex-encoded sha256 digest of a request body, used to detect whether a
+// replayed idempotency key is being reused with a different payload.
+func hashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// responseRecorder captures the status code and body written by the wrapped handler so they
+// can be persisted for idempotent replay.
+type responseRecorder struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,176 @@
+package idempotency
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"lugbit/projects/checkout/database"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+// okHandler is the default downstream handler used by most test cases: it always succeeds.
+func okHandler(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "application/json", []byte(`{"ok":true}`))
+}
+
+// abortingHandler simulates a handler that rejects the request, e.g. PurchaseItems hitting
+// ErrInsufficientStock, the same way ctx.AbortWithStatusJSON is used throughout the codebase.
+func abortingHandler(ctx *gin.Context) {
+	ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "insufficient quantity for sku: 120P90"})
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name             string
+		idempotencyKey   string
+		requestBody      string
+		handler          gin.HandlerFunc
+		mockSetup        func(mock sqlmock.Sqlmock)
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			name:           "no idempotency key provided",
+			idempotencyKey: "",
+			requestBody:    `{"sku":"120P90"}`,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusOK,
+			// no key means the middleware never touches the DB and the handler always runs.
+			expectedResponse: `{"ok":true}`,
+		},
+		{
+			name:           "fresh key claims the row, executes, and is stored",
+			idempotencyKey: "key-1",
+			requestBody:    `{"sku":"120P90"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO idempotency_keys").
+					WithArgs("key-1", "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE idempotency_keys SET response_body = \\$1, status_code = \\$2 WHERE key = \\$3 AND user_id = \\$4").
+					WithArgs(`{"ok":true}`, http.StatusOK, "key-1", "").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"ok":true}`,
+		},
+		{
+			name:           "replayed key with identical body returns cached response",
+			idempotencyKey: "key-2",
+			requestBody:    `{"sku":"120P90"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO idempotency_keys").
+					WithArgs("key-2", "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				rows := sqlmock.NewRows([]string{"request_hash", "response_body", "status_code"}).
+					AddRow(hashBody([]byte(`{"sku":"120P90"}`)), `{"ok":true}`, http.StatusOK)
+				mock.ExpectQuery("SELECT request_hash, response_body, status_code FROM idempotency_keys").
+					WithArgs("key-2", "").
+					WillReturnRows(rows)
+			},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"ok":true}`,
+		},
+		{
+			name:           "replayed key with different body is rejected",
+			idempotencyKey: "key-3",
+			requestBody:    `{"sku":"DIFFERENT"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO idempotency_keys").
+					WithArgs("key-3", "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				rows := sqlmock.NewRows([]string{"request_hash", "response_body", "status_code"}).
+					AddRow(hashBody([]byte(`{"sku":"120P90"}`)), `{"ok":true}`, http.StatusOK)
+				mock.ExpectQuery("SELECT request_hash, response_body, status_code FROM idempotency_keys").
+					WithArgs("key-3", "").
+					WillReturnRows(rows)
+			},
+			expectedStatus:   http.StatusUnprocessableEntity,
+			expectedResponse: `{"error":"idempotency key already used with a different request"}`,
+		},
+		{
+			name:           "concurrent duplicate loses the claim and is rejected while the original is still in flight",
+			idempotencyKey: "key-4",
+			requestBody:    `{"sku":"120P90"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO idempotency_keys").
+					WithArgs("key-4", "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				// the in-flight holder's row has status_code 0: it claimed the key but hasn't
+				// finished executing yet, so there's no cached response to replay.
+				rows := sqlmock.NewRows([]string{"request_hash", "response_body", "status_code"}).
+					AddRow(hashBody([]byte(`{"sku":"120P90"}`)), "", 0)
+				mock.ExpectQuery("SELECT request_hash, response_body, status_code FROM idempotency_keys").
+					WithArgs("key-4", "").
+					WillReturnRows(rows)
+			},
+			expectedStatus:   http.StatusConflict,
+			expectedResponse: `{"error":"a request with this idempotency key is already in progress"}`,
+		},
+		{
+			name:           "handler abort is persisted so a retry replays the error instead of racing again",
+			idempotencyKey: "key-5",
+			requestBody:    `{"sku":"120P90"}`,
+			handler:        abortingHandler,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO idempotency_keys").
+					WithArgs("key-5", "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE idempotency_keys SET response_body = \\$1, status_code = \\$2 WHERE key = \\$3 AND user_id = \\$4").
+					WithArgs(`{"error":"insufficient quantity for sku: 120P90"}`, http.StatusBadRequest, "key-5", "").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: `{"error":"insufficient quantity for sku: 120P90"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock database: %s", err)
+			}
+			defer db.Close()
+			database.Db = db
+
+			tc.mockSetup(mock)
+
+			handler := tc.handler
+			if handler == nil {
+				handler = okHandler
+			}
+
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+			router.POST("/widget", Middleware(), handler)
+
+			req, err := http.NewRequest(http.MethodPost, "/widget", bytes.NewBufferString(tc.requestBody))
+			if err != nil {
+				t.Fatalf("failed to create HTTP request: %s", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			if tc.idempotencyKey != "" {
+				req.Header.Set("Idempotency-Key", tc.idempotencyKey)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+
+			if body := rr.Body.String(); body != tc.expectedResponse {
+				t.Errorf("expected response %s, got %s", tc.expectedResponse, body)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
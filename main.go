@@ -1,32 +1,102 @@
 package main
 
 import (
+	"fmt"
+	"log"
+	"net"
+
+	"lugbit/projects/checkout/cart"
 	"lugbit/projects/checkout/database"
+	checkoutgrpc "lugbit/projects/checkout/grpc"
+	"lugbit/projects/checkout/grpc/pb"
+	"lugbit/projects/checkout/idempotency"
+	"lugbit/projects/checkout/order"
 	product "lugbit/projects/checkout/product"
+	"lugbit/projects/checkout/user"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+)
+
+const (
+	httpAddr = ":8080"
+	grpcAddr = ":9090"
 )
 
 func main() {
-	route := gin.Default()
 	// connect to DB
 	database.ConnectDatabase()
 
+	go runGRPCServer()
+	runHTTPServer()
+}
+
+// runHTTPServer starts the Gin HTTP server with the product, purchase, and cart routes.
+func runHTTPServer() {
+	route := gin.Default()
+
 	// routes
 	const (
-		productURL  = "/product"
-		purchaseURL = "/purchase"
+		productURL     = "/product"
+		purchaseURL    = "/purchase"
+		cartURL        = "/cart"
+		registerURL    = "/register"
+		loginURL       = "/login"
+		refreshURL     = "/refresh"
+		ordersURL      = "/orders"
+		orderStatusURL = "/orders/:id/status"
 	)
 
+	// register POST
+	route.POST(registerURL, user.Register)
+	// login POST
+	route.POST(loginURL, user.Login)
+	// refresh access token POST
+	route.POST(refreshURL, user.RefreshTokenHandler)
+
 	// list products GET
 	route.GET(productURL, product.ListProducts)
-	// add product POST
-	route.POST(productURL, product.AddProduct)
-	// purchase item POST
-	route.POST(purchaseURL, product.PurchaseItems)
+	// add product POST, honors Idempotency-Key to make retries safe
+	route.POST(productURL, idempotency.Middleware(), product.AddProduct)
+	// purchase item POST, requires a logged-in user and honors Idempotency-Key
+	route.POST(purchaseURL, user.RequireAuth(), idempotency.Middleware(), product.PurchaseItems)
+
+	// add-or-update cart item POST, requires a logged-in user
+	route.POST(cartURL, user.RequireAuth(), cart.UpsertCartItem)
+	// remove cart item DELETE, requires a logged-in user
+	route.DELETE(cartURL+"/:sku", user.RequireAuth(), cart.RemoveCartItem)
+	// list cart GET, requires a logged-in user
+	route.GET(cartURL, user.RequireAuth(), cart.ListCart)
 
-	err := route.Run(":8080")
+	// list orders GET
+	route.GET(ordersURL, user.RequireAuth(), order.ListOrders)
+	// order detail GET
+	route.GET(ordersURL+"/:id", user.RequireAuth(), order.GetOrder)
+	// order status transition PATCH
+	route.PATCH(orderStatusURL, user.RequireAuth(), order.UpdateOrderStatus)
+
+	err := route.Run(httpAddr)
 	if err != nil {
 		panic(err)
 	}
 }
+
+// runGRPCServer starts the gRPC server exposing ProductService and CheckoutService alongside
+// the HTTP server, sharing the same database connection and service layer.
+func runGRPCServer() {
+	lis, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		log.Fatalf("failed to listen on %s: %v", grpcAddr, err)
+	}
+
+	server := checkoutgrpc.NewServer()
+
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(checkoutgrpc.UnaryAuthInterceptor))
+	pb.RegisterProductServiceServer(grpcServer, server)
+	pb.RegisterCheckoutServiceServer(grpcServer, server)
+
+	fmt.Printf("gRPC server listening on %s\n", grpcAddr)
+	if err := grpcServer.Serve(lis); err != nil {
+		log.Fatalf("gRPC server failed: %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package order
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"lugbit/projects/checkout/database"
+	"lugbit/projects/checkout/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListOrders returns every order placed by the authenticated user, most recent first.
+func ListOrders(ctx *gin.Context) {
+	userID, ok := user.UserIDFromContext(ctx)
+	if !ok || userID == "" {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	rows, err := database.Db.Query(
+		"SELECT id, user_id, total_price, status, created_at FROM orders WHERE user_id = $1 ORDER BY created_at DESC",
+		userID,
+	)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "error fetching orders"})
+		return
+	}
+	defer rows.Close()
+
+	orders := []Order{}
+	for rows.Next() {
+		var o Order
+		if err := rows.Scan(&o.ID, &o.UserID, &o.TotalPrice, &o.Status, &o.CreatedAt); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "error scanning into struct: " + err.Error()})
+			return
+		}
+		orders = append(orders, o)
+	}
+
+	if err := rows.Err(); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "error processing rows"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, orders)
+}
+
+// GetOrder returns line-item detail for a single order belonging to the authenticated user.
+func GetOrder(ctx *gin.Context) {
+	userID, ok := user.UserIDFromContext(ctx)
+	if !ok || userID == "" {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	orderID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+
+	var detail OrderDetail
+	row := database.Db.QueryRow(
+		"SELECT id, user_id, total_price, status, created_at FROM orders WHERE id = $1 AND user_id = $2",
+		orderID, userID,
+	)
+	if err := row.Scan(&detail.ID, &detail.UserID, &detail.TotalPrice, &detail.Status, &detail.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("order not found: %d", orderID)})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "error fetching order"})
+		return
+	}
+
+	itemRows, err := database.Db.Query("SELECT sku, qty, unit_price FROM order_items WHERE order_id = $1", orderID)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "error fetching order items"})
+		return
+	}
+	defer itemRows.Close()
+
+	detail.Items = []OrderItem{}
+	for itemRows.Next() {
+		var item OrderItem
+		if err := itemRows.Scan(&item.SKU, &item.Qty, &item.UnitPrice); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "error scanning into struct: " + err.Error()})
+			return
+		}
+		detail.Items = append(detail.Items, item)
+	}
+
+	if err := itemRows.Err(); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "error processing rows"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, detail)
+}
+
+// UpdateStatusRequest names the status an order should transition to.
+type UpdateStatusRequest struct {
+	Status string `json:"status"`
+}
+
+// UpdateOrderStatus transitions an order to a new status, refusing the request if the
+// transition is not legal for the order's current status (pending -> paid -> shipped ->
+// delivered, or pending/paid -> cancelled).
+func UpdateOrderStatus(ctx *gin.Context) {
+	userID, ok := user.UserIDFromContext(ctx)
+	if !ok || userID == "" {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	orderID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+
+	var req UpdateStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid JSON body"})
+		return
+	}
+
+	// read, validate, and write the transition inside a single transaction, locking the order
+	// row so two concurrent PATCH requests can't both pass validation against the same
+	// currentStatus and race each other to a write.
+	tx, err := database.Db.Begin()
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "error fetching order"})
+		return
+	}
+
+	var currentStatus string
+	row := tx.QueryRow("SELECT status FROM orders WHERE id = $1 AND user_id = $2 FOR UPDATE", orderID, userID)
+	if err := row.Scan(&currentStatus); err != nil {
+		_ = tx.Rollback()
+		if err == sql.ErrNoRows {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("order not found: %d", orderID)})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "error fetching order"})
+		return
+	}
+
+	if !canTransition(currentStatus, req.Status) {
+		_ = tx.Rollback()
+		ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			"error": fmt.Sprintf("cannot transition order from %s to %s", currentStatus, req.Status),
+		})
+		return
+	}
+
+	if _, err := tx.Exec("UPDATE orders SET status = $1 WHERE id = $2", req.Status, orderID); err != nil {
+		_ = tx.Rollback()
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to update order status"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to update order status"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"id": orderID, "status": req.Status})
+}
@@ -0,0 +1,88 @@
+package order
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Status values for the order status transition machine.
+const (
+	StatusPending   = "pending"
+	StatusPaid      = "paid"
+	StatusShipped   = "shipped"
+	StatusDelivered = "delivered"
+	StatusCancelled = "cancelled"
+)
+
+// allowedTransitions enumerates which status transitions are legal. A status with no entry
+// (StatusDelivered, StatusCancelled) is terminal.
+var allowedTransitions = map[string][]string{
+	StatusPending: {StatusPaid, StatusCancelled},
+	StatusPaid:    {StatusShipped, StatusCancelled},
+	StatusShipped: {StatusDelivered},
+}
+
+// LineItem is a single sku/qty/unit-price line persisted as part of an order.
+type LineItem struct {
+	SKU       string
+	Qty       int
+	UnitPrice float64
+}
+
+// Order is a purchase recorded for a user, with its current fulfillment status.
+type Order struct {
+	ID         int64     `json:"id"`
+	UserID     string    `json:"user_id"`
+	TotalPrice float64   `json:"total_price"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// OrderItem is a single line of an order, as returned in order detail responses.
+type OrderItem struct {
+	SKU       string  `json:"sku"`
+	Qty       int     `json:"qty"`
+	UnitPrice float64 `json:"unit_price"`
+}
+
+// OrderDetail is an order together with its line items.
+type OrderDetail struct {
+	Order
+	Items []OrderItem `json:"items"`
+}
+
+// CreateTx inserts a new order and its line items inside an existing transaction, returning the
+// generated order id. It is called from product.PurchaseService so the order is recorded
+// atomically with the inventory update.
+func CreateTx(tx *sql.Tx, userID string, items []LineItem, totalPrice float64) (int64, error) {
+	var orderID int64
+	row := tx.QueryRow(
+		"INSERT INTO orders (user_id, total_price, status, created_at) VALUES ($1, $2, $3, now()) RETURNING id",
+		userID, totalPrice, StatusPending,
+	)
+	if err := row.Scan(&orderID); err != nil {
+		return 0, err
+	}
+
+	for _, item := range items {
+		_, err := tx.Exec(
+			"INSERT INTO order_items (order_id, sku, qty, unit_price) VALUES ($1, $2, $3, $4)",
+			orderID, item.SKU, item.Qty, item.UnitPrice,
+		)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return orderID, nil
+}
+
+// canTransition reports whether an order may move from the given current status to next.
+func canTransition(current, next string) bool {
+	for _, allowed := range allowedTransitions[current] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,292 @@
+package order
+
+import (
+	"bytes"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"lugbit/projects/checkout/database"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+// withUserID simulates the user.RequireAuth middleware injecting the authenticated user id.
+func withUserID(userID string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Set("user_id", userID)
+		ctx.Next()
+	}
+}
+
+func TestListOrders(t *testing.T) {
+	var userID = "999"
+	tests := []struct {
+		name             string
+		setUserID        bool
+		mockSetup        func(mock sqlmock.Sqlmock)
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			name:             "user is not authenticated",
+			setUserID:        false,
+			mockSetup:        func(mock sqlmock.Sqlmock) {},
+			expectedStatus:   http.StatusUnauthorized,
+			expectedResponse: `{"error":"authentication required"}`,
+		},
+		{
+			name:      "returns orders most recent first",
+			setUserID: true,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				createdAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+				rows := sqlmock.NewRows([]string{"id", "user_id", "total_price", "status", "created_at"}).
+					AddRow(int64(77), userID, 40.0, StatusPending, createdAt)
+				mock.ExpectQuery("SELECT id, user_id, total_price, status, created_at FROM orders WHERE user_id = \\$1 ORDER BY created_at DESC").
+					WithArgs(userID).
+					WillReturnRows(rows)
+			},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `[{"id":77,"user_id":"999","total_price":40,"status":"pending","created_at":"2024-01-01T12:00:00Z"}]`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock database: %s", err)
+			}
+			defer db.Close()
+			database.Db = db
+
+			tc.mockSetup(mock)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+			router.GET("/orders", func(ctx *gin.Context) {
+				if tc.setUserID {
+					ctx.Set("user_id", userID)
+				}
+				ctx.Next()
+			}, ListOrders)
+
+			req, err := http.NewRequest(http.MethodGet, "/orders", nil)
+			if err != nil {
+				t.Fatalf("failed to create HTTP request: %s", err)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+
+			if body := rr.Body.String(); body != tc.expectedResponse {
+				t.Errorf("expected response %s, got %s", tc.expectedResponse, body)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestGetOrder(t *testing.T) {
+	var userID = "999"
+	tests := []struct {
+		name             string
+		orderID          string
+		mockSetup        func(mock sqlmock.Sqlmock)
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			name:    "order not found",
+			orderID: "77",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT id, user_id, total_price, status, created_at FROM orders WHERE id = \\$1 AND user_id = \\$2").
+					WithArgs(int64(77), userID).
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedStatus:   http.StatusNotFound,
+			expectedResponse: `{"error":"order not found: 77"}`,
+		},
+		{
+			name:    "returns order detail with line items",
+			orderID: "77",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				createdAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+				orderRows := sqlmock.NewRows([]string{"id", "user_id", "total_price", "status", "created_at"}).
+					AddRow(int64(77), userID, 40.0, StatusPending, createdAt)
+				mock.ExpectQuery("SELECT id, user_id, total_price, status, created_at FROM orders WHERE id = \\$1 AND user_id = \\$2").
+					WithArgs(int64(77), userID).
+					WillReturnRows(orderRows)
+
+				itemRows := sqlmock.NewRows([]string{"sku", "qty", "unit_price"}).
+					AddRow("120P90", 2, 10.0).
+					AddRow("43N23P", 1, 20.0)
+				mock.ExpectQuery("SELECT sku, qty, unit_price FROM order_items WHERE order_id = \\$1").
+					WithArgs(int64(77)).
+					WillReturnRows(itemRows)
+			},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"id":77,"user_id":"999","total_price":40,"status":"pending","created_at":"2024-01-01T12:00:00Z","items":[{"sku":"120P90","qty":2,"unit_price":10},{"sku":"43N23P","qty":1,"unit_price":20}]}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock database: %s", err)
+			}
+			defer db.Close()
+			database.Db = db
+
+			tc.mockSetup(mock)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+			router.GET("/orders/:id", withUserID(userID), GetOrder)
+
+			req, err := http.NewRequest(http.MethodGet, "/orders/"+tc.orderID, nil)
+			if err != nil {
+				t.Fatalf("failed to create HTTP request: %s", err)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+
+			if body := rr.Body.String(); body != tc.expectedResponse {
+				t.Errorf("expected response %s, got %s", tc.expectedResponse, body)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestUpdateOrderStatus(t *testing.T) {
+	var userID = "999"
+	tests := []struct {
+		name             string
+		orderID          string
+		requestBody      string
+		mockSetup        func(mock sqlmock.Sqlmock)
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			name:        "order not found",
+			orderID:     "77",
+			requestBody: `{"status":"paid"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT status FROM orders WHERE id = \\$1 AND user_id = \\$2 FOR UPDATE").
+					WithArgs(int64(77), userID).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			expectedStatus:   http.StatusNotFound,
+			expectedResponse: `{"error":"order not found: 77"}`,
+		},
+		{
+			name:        "illegal transition is rejected",
+			orderID:     "77",
+			requestBody: `{"status":"shipped"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"status"}).AddRow(StatusPending)
+				mock.ExpectQuery("SELECT status FROM orders WHERE id = \\$1 AND user_id = \\$2 FOR UPDATE").
+					WithArgs(int64(77), userID).
+					WillReturnRows(rows)
+				mock.ExpectRollback()
+			},
+			expectedStatus:   http.StatusConflict,
+			expectedResponse: `{"error":"cannot transition order from pending to shipped"}`,
+		},
+		{
+			name:        "terminal status rejects every transition",
+			orderID:     "77",
+			requestBody: `{"status":"paid"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"status"}).AddRow(StatusDelivered)
+				mock.ExpectQuery("SELECT status FROM orders WHERE id = \\$1 AND user_id = \\$2 FOR UPDATE").
+					WithArgs(int64(77), userID).
+					WillReturnRows(rows)
+				mock.ExpectRollback()
+			},
+			expectedStatus:   http.StatusConflict,
+			expectedResponse: `{"error":"cannot transition order from delivered to paid"}`,
+		},
+		{
+			name:        "legal transition commits the update",
+			orderID:     "77",
+			requestBody: `{"status":"paid"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"status"}).AddRow(StatusPending)
+				mock.ExpectQuery("SELECT status FROM orders WHERE id = \\$1 AND user_id = \\$2 FOR UPDATE").
+					WithArgs(int64(77), userID).
+					WillReturnRows(rows)
+				mock.ExpectExec("UPDATE orders SET status = \\$1 WHERE id = \\$2").
+					WithArgs(StatusPaid, int64(77)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"id":77,"status":"paid"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock database: %s", err)
+			}
+			defer db.Close()
+			database.Db = db
+
+			tc.mockSetup(mock)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+			router.PATCH("/orders/:id/status", withUserID(userID), UpdateOrderStatus)
+
+			req, err := http.NewRequest(http.MethodPatch, "/orders/"+tc.orderID+"/status", bytes.NewBufferString(tc.requestBody))
+			if err != nil {
+				t.Fatalf("failed to create HTTP request: %s", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+
+			if body := rr.Body.String(); body != tc.expectedResponse {
+				t.Errorf("expected response %s, got %s", tc.expectedResponse, body)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package product
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// stockLevel is a product's price and available quantity, as read by the batched stock lookup
+// in PurchaseService.
+type stockLevel struct {
+	price float64
+	qty   int
+}
+
+// aggregateItems collapses duplicate SKUs in items into a single quantity per SKU, and returns
+// the distinct SKUs in a deterministic (sorted) order so that concurrent purchases with
+// overlapping SKU sets always lock rows in the same order, avoiding deadlocks.
+func aggregateItems(items []PurchaseItem) ([]string, map[string]int) {
+	qtyBySKU := make(map[string]int, len(items))
+	for _, item := range items {
+		qtyBySKU[item.SKU] += item.Qty
+	}
+
+	skus := make([]string, 0, len(qtyBySKU))
+	for sku := range qtyBySKU {
+		skus = append(skus, sku)
+	}
+	sort.Strings(skus)
+
+	return skus, qtyBySKU
+}
+
+// bulkUpdateQuery builds a single UPDATE ... FROM (VALUES ...) statement that decrements the
+// stock of every given SKU by its purchased quantity in one round trip.
+func bulkUpdateQuery(skus []string, qtyBySKU map[string]int) (string, []interface{}) {
+	values := make([]string, len(skus))
+	args := make([]interface{}, 0, len(skus)*2)
+
+	for i, sku := range skus {
+		values[i] = fmt.Sprintf("($%d::text, $%d::int)", i*2+1, i*2+2)
+		args = append(args, sku, qtyBySKU[sku])
+	}
+
+	query := fmt.Sprintf(
+		"UPDATE product SET qty = qty - v.qty FROM (VALUES %s) AS v(sku, qty) WHERE product.sku = v.sku",
+		strings.Join(values, ", "),
+	)
+
+	return query, args
+}
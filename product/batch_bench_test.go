@@ -0,0 +1,34 @@
+package product
+
+import (
+	"fmt"
+	"testing"
+)
+
+func BenchmarkAggregateItems(b *testing.B) {
+	items := benchItems(100)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		aggregateItems(items)
+	}
+}
+
+func BenchmarkBulkUpdateQuery(b *testing.B) {
+	skus, qtyBySKU := aggregateItems(benchItems(100))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bulkUpdateQuery(skus, qtyBySKU)
+	}
+}
+
+// benchItems returns n cart lines spread across 25 distinct SKUs, so both benchmarks exercise
+// the duplicate-SKU aggregation path.
+func benchItems(n int) []PurchaseItem {
+	items := make([]PurchaseItem, 0, n)
+	for i := 0; i < n; i++ {
+		items = append(items, PurchaseItem{SKU: fmt.Sprintf("SKU-%03d", i%25), Qty: 1})
+	}
+	return items
+}
@@ -0,0 +1,28 @@
+package product
+
+import "fmt"
+
+// ErrUserIDRequired is returned by the service layer when no user id was supplied for an
+// operation that requires one.
+var ErrUserIDRequired = fmt.Errorf("user id required")
+
+// ErrCartEmpty is returned when a purchase is attempted against an empty cart.
+var ErrCartEmpty = fmt.Errorf("cart is empty")
+
+// ErrNotFoundSKU indicates the given SKU does not exist in the product table.
+type ErrNotFoundSKU struct {
+	SKU string
+}
+
+func (e *ErrNotFoundSKU) Error() string {
+	return fmt.Sprintf("product not found or error scanning for sku: %s", e.SKU)
+}
+
+// ErrInsufficientStock indicates the requested quantity for a SKU exceeds what is in stock.
+type ErrInsufficientStock struct {
+	SKU string
+}
+
+func (e *ErrInsufficientStock) Error() string {
+	return fmt.Sprintf("insufficient quantity for sku: %s", e.SKU)
+}
@@ -1,9 +1,6 @@
 package product
 
 import (
-	"bytes"
-	"database/sql"
-	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -18,107 +15,126 @@ func TestPurchaseItems(t *testing.T) {
 	var userID = "999"
 	tests := []struct {
 		name             string
-		requestBody      PurchaseRequest
+		contextUserID    string
+		setUserID        bool
 		mockSetup        func(mock sqlmock.Sqlmock)
 		expectedStatus   int
 		expectedResponse string
 	}{
 		{
-			name: "user ID is empty (user not logged in)",
-			requestBody: PurchaseRequest{
-				UserID: "",
-				Items: []PurchaseItem{
-					{SKU: "120P90", Qty: 2},
-					{SKU: "43N23P", Qty: 1},
-				},
-			},
+			name:      "user is not authenticated",
+			setUserID: false,
 			mockSetup: func(mock sqlmock.Sqlmock) {
 			},
-			expectedStatus:   http.StatusBadRequest,
-			expectedResponse: `{"error":"user id required"}`,
+			expectedStatus:   http.StatusUnauthorized,
+			expectedResponse: `{"error":"authentication required"}`,
 		},
 		{
-			name: "successful purchase of multiple items",
-			requestBody: PurchaseRequest{
-				UserID: userID,
-				Items: []PurchaseItem{
-					{SKU: "120P90", Qty: 2},
-					{SKU: "43N23P", Qty: 1},
-				},
-			},
+			name:          "successful purchase of multiple items",
+			setUserID:     true,
+			contextUserID: userID,
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				// For "120P90": available 5, price 10.0
-				rows1 := sqlmock.NewRows([]string{"price", "qty"}).AddRow(10.0, 5)
-				mock.ExpectQuery("SELECT price, qty FROM product WHERE sku = \\$1 FOR UPDATE").
-					WithArgs("120P90").
-					WillReturnRows(rows1)
-				mock.ExpectExec("UPDATE product SET qty = qty - \\$1 WHERE sku = \\$2").
-					WithArgs(2, "120P90").
-					WillReturnResult(sqlmock.NewResult(1, 1))
 
-				// For "43N23P": available 2, price 20.0
-				rows2 := sqlmock.NewRows([]string{"price", "qty"}).AddRow(20.0, 2)
-				mock.ExpectQuery("SELECT price, qty FROM product WHERE sku = \\$1 FOR UPDATE").
-					WithArgs("43N23P").
-					WillReturnRows(rows2)
-				mock.ExpectExec("UPDATE product SET qty = qty - \\$1 WHERE sku = \\$2").
-					WithArgs(1, "43N23P").
+				cartRows := sqlmock.NewRows([]string{"sku", "qty"}).
+					AddRow("120P90", 2).
+					AddRow("43N23P", 1)
+				mock.ExpectQuery("SELECT sku, qty FROM cart WHERE user_id = \\$1").
+					WithArgs(userID).
+					WillReturnRows(cartRows)
+
+				// SKUs are locked in a single batched query, sorted: "120P90" before "43N23P".
+				stockRows := sqlmock.NewRows([]string{"sku", "price", "qty"}).
+					AddRow("120P90", 10.0, 5).
+					AddRow("43N23P", 20.0, 2)
+				mock.ExpectQuery("SELECT sku, price, qty FROM product WHERE sku = ANY\\(\\$1\\) ORDER BY sku FOR UPDATE").
+					WithArgs(sqlmock.AnyArg()).
+					WillReturnRows(stockRows)
+
+				mock.ExpectExec("UPDATE product SET qty = qty - v\\.qty FROM \\(VALUES \\(\\$1::text, \\$2::int\\), \\(\\$3::text, \\$4::int\\)\\) AS v\\(sku, qty\\) WHERE product\\.sku = v\\.sku").
+					WithArgs("120P90", 2, "43N23P", 1).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+
+				orderRows := sqlmock.NewRows([]string{"id"}).AddRow(77)
+				mock.ExpectQuery("INSERT INTO orders \\(user_id, total_price, status, created_at\\) VALUES \\(\\$1, \\$2, \\$3, now\\(\\)\\) RETURNING id").
+					WithArgs(userID, 40.0, "pending").
+					WillReturnRows(orderRows)
+				mock.ExpectExec("INSERT INTO order_items \\(order_id, sku, qty, unit_price\\) VALUES \\(\\$1, \\$2, \\$3, \\$4\\)").
+					WithArgs(int64(77), "120P90", 2, 10.0).
 					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO order_items \\(order_id, sku, qty, unit_price\\) VALUES \\(\\$1, \\$2, \\$3, \\$4\\)").
+					WithArgs(int64(77), "43N23P", 1, 20.0).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+
+				mock.ExpectExec("DELETE FROM cart WHERE user_id = \\$1").
+					WithArgs(userID).
+					WillReturnResult(sqlmock.NewResult(0, 2))
 
 				mock.ExpectCommit()
 			},
 			expectedStatus: http.StatusOK,
 			// Total price: (10.0 * 2) + (20.0 * 1) = 40.0.
-			expectedResponse: `{"user_id":"999","items_purchased":[{"sku":"120P90","qty":2},{"sku":"43N23P","qty":1}],"total_price":40}`,
+			expectedResponse: `{"user_id":"999","order_id":77,"items_purchased":[{"sku":"120P90","qty":2},{"sku":"43N23P","qty":1}],"total_price":40}`,
 		},
 		{
-			name: "insufficient quantity for item",
-			requestBody: PurchaseRequest{
-				UserID: userID,
-				Items: []PurchaseItem{
-					{SKU: "120P90", Qty: 3},
-				},
-			},
+			name:          "insufficient quantity for item",
+			setUserID:     true,
+			contextUserID: userID,
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
 
-				// "120P90" available qty 2, but request is 3
-				rows := sqlmock.NewRows([]string{"price", "qty"}).AddRow(10.0, 2)
-				mock.ExpectQuery("SELECT price, qty FROM product WHERE sku = \\$1 FOR UPDATE").
-					WithArgs("120P90").
-					WillReturnRows(rows)
+				cartRows := sqlmock.NewRows([]string{"sku", "qty"}).AddRow("120P90", 3)
+				mock.ExpectQuery("SELECT sku, qty FROM cart WHERE user_id = \\$1").
+					WithArgs(userID).
+					WillReturnRows(cartRows)
+
+				// "120P90" available qty 2, but cart wants 3
+				stockRows := sqlmock.NewRows([]string{"sku", "price", "qty"}).AddRow("120P90", 10.0, 2)
+				mock.ExpectQuery("SELECT sku, price, qty FROM product WHERE sku = ANY\\(\\$1\\) ORDER BY sku FOR UPDATE").
+					WithArgs(sqlmock.AnyArg()).
+					WillReturnRows(stockRows)
 				mock.ExpectRollback()
 			},
 			expectedStatus:   http.StatusBadRequest,
 			expectedResponse: `{"error":"insufficient quantity for sku: 120P90"}`,
 		},
 		{
-			name: "product not found",
-			requestBody: PurchaseRequest{
-				UserID: userID,
-				Items: []PurchaseItem{
-					{SKU: "UNKNOWN", Qty: 1},
-				},
-			},
+			name:          "product not found",
+			setUserID:     true,
+			contextUserID: userID,
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				mock.ExpectQuery("SELECT price, qty FROM product WHERE sku = \\$1 FOR UPDATE").
-					WithArgs("UNKNOWN").
-					WillReturnError(sql.ErrNoRows)
+
+				cartRows := sqlmock.NewRows([]string{"sku", "qty"}).AddRow("UNKNOWN", 1)
+				mock.ExpectQuery("SELECT sku, qty FROM cart WHERE user_id = \\$1").
+					WithArgs(userID).
+					WillReturnRows(cartRows)
+
+				// "UNKNOWN" doesn't exist, so the batched lookup returns no matching rows.
+				stockRows := sqlmock.NewRows([]string{"sku", "price", "qty"})
+				mock.ExpectQuery("SELECT sku, price, qty FROM product WHERE sku = ANY\\(\\$1\\) ORDER BY sku FOR UPDATE").
+					WithArgs(sqlmock.AnyArg()).
+					WillReturnRows(stockRows)
 				mock.ExpectRollback()
 			},
 			expectedStatus:   http.StatusBadRequest,
 			expectedResponse: `{"error":"product not found or error scanning for sku: UNKNOWN"}`,
 		},
 		{
-			name:        "invalid JSON body",
-			requestBody: PurchaseRequest{
-				// We'll override the JSON marshalling to send invalid JSON in this case.
+			name:          "cart is empty",
+			setUserID:     true,
+			contextUserID: userID,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+
+				cartRows := sqlmock.NewRows([]string{"sku", "qty"})
+				mock.ExpectQuery("SELECT sku, qty FROM cart WHERE user_id = \\$1").
+					WithArgs(userID).
+					WillReturnRows(cartRows)
+				mock.ExpectRollback()
 			},
-			mockSetup:        func(mock sqlmock.Sqlmock) {},
 			expectedStatus:   http.StatusBadRequest,
-			expectedResponse: `{"error":"invalid JSON body"}`,
+			expectedResponse: `{"error":"cart is empty"}`,
 		},
 	}
 
@@ -137,24 +153,18 @@ func TestPurchaseItems(t *testing.T) {
 
 			gin.SetMode(gin.TestMode)
 			router := gin.Default()
-			router.POST("/purchase", PurchaseItems)
-
-			var reqBody []byte
-			// this is to simulate invalid json body test case
-			if tc.name == "invalid JSON body" {
-				reqBody = []byte("invalid-json")
-			} else {
-				reqBody, err = json.Marshal(tc.requestBody)
-				if err != nil {
-					t.Fatalf("error marshalling request body: %s", err)
+			// simulate the user.RequireAuth middleware injecting the authenticated user id
+			router.POST("/purchase", func(ctx *gin.Context) {
+				if tc.setUserID {
+					ctx.Set("user_id", tc.contextUserID)
 				}
-			}
+				ctx.Next()
+			}, PurchaseItems)
 
-			req, err := http.NewRequest(http.MethodPost, "/purchase", bytes.NewBuffer(reqBody))
+			req, err := http.NewRequest(http.MethodPost, "/purchase", nil)
 			if err != nil {
 				t.Fatalf("failed to create HTTP request: %s", err)
 			}
-			req.Header.Set("Content-Type", "application/json")
 
 			rr := httptest.NewRecorder()
 			router.ServeHTTP(rr, req)
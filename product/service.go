@@ -0,0 +1,181 @@
+package product
+
+import (
+	"lugbit/projects/checkout/database"
+	"lugbit/projects/checkout/order"
+
+	"github.com/lib/pq"
+)
+
+// ListProductsService returns every product currently in the catalog. It contains no
+// transport-specific logic so it can be reused by both the HTTP and gRPC handlers.
+func ListProductsService() ([]Product, error) {
+	query := "SELECT sku, name, price, qty FROM product"
+	rows, err := database.Db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []Product
+	for rows.Next() {
+		var p Product
+		if err := rows.Scan(&p.SKU, &p.Name, &p.Price, &p.Qty); err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// AddProductService inserts a new product into the catalog. If the SKU already exists, the
+// insert will fail.
+func AddProductService(p Product) error {
+	query := "INSERT INTO product (sku, name, price, qty) VALUES ($1, $2, $3, $4)"
+
+	stmt, err := database.Db.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(p.SKU, p.Name, p.Price, p.Qty)
+	return err
+}
+
+// PurchaseService "purchases" the caller's current cart contents from the inventory. If
+// inventory stock of an item is less than the quantity held in the cart, the purchase will
+// fail with an *ErrInsufficientStock. It contains no transport-specific logic so it can be
+// reused by both the HTTP and gRPC handlers.
+func PurchaseService(userID string) (*PurchaseResponse, error) {
+	if userID == "" {
+		return nil, ErrUserIDRequired
+	}
+
+	// start db transaction so that we can roll back if any of the queries fail.
+	tx, err := database.Db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	// load the cart contents for this user inside the transaction so purchase operates on a
+	// consistent snapshot of the cart.
+	cartRows, err := tx.Query("SELECT sku, qty FROM cart WHERE user_id = $1", userID)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	var items []PurchaseItem
+	for cartRows.Next() {
+		var item PurchaseItem
+		if err := cartRows.Scan(&item.SKU, &item.Qty); err != nil {
+			cartRows.Close()
+			_ = tx.Rollback()
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	cartRows.Close()
+
+	if len(items) == 0 {
+		_ = tx.Rollback()
+		return nil, ErrCartEmpty
+	}
+
+	// aggregate duplicate SKUs and lock rows in a deterministic (sorted) order so concurrent
+	// purchases with overlapping SKU sets can't deadlock against each other.
+	skus, qtyBySKU := aggregateItems(items)
+
+	// a single batched SELECT ... FOR UPDATE replaces one round trip per SKU.
+	stockRows, err := tx.Query(
+		"SELECT sku, price, qty FROM product WHERE sku = ANY($1) ORDER BY sku FOR UPDATE",
+		pq.Array(skus),
+	)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	available := make(map[string]stockLevel, len(skus))
+	for stockRows.Next() {
+		var sku string
+		var level stockLevel
+		if err := stockRows.Scan(&sku, &level.price, &level.qty); err != nil {
+			stockRows.Close()
+			_ = tx.Rollback()
+			return nil, err
+		}
+		available[sku] = level
+	}
+	stockRows.Close()
+
+	// validate every SKU's availability in-memory before issuing any writes.
+	totalPrice := 0.0
+	lineItems := make([]order.LineItem, 0, len(skus))
+	for _, sku := range skus {
+		stock, ok := available[sku]
+		if !ok {
+			_ = tx.Rollback()
+			return nil, &ErrNotFoundSKU{SKU: sku}
+		}
+
+		qty := qtyBySKU[sku]
+		if stock.qty < qty {
+			_ = tx.Rollback()
+			return nil, &ErrInsufficientStock{SKU: sku}
+		}
+
+		totalPrice += stock.price * float64(qty)
+		lineItems = append(lineItems, order.LineItem{SKU: sku, Qty: qty, UnitPrice: stock.price})
+	}
+
+	// a single bulk UPDATE decrements every SKU's stock in one round trip.
+	updateQuery, updateArgs := bulkUpdateQuery(skus, qtyBySKU)
+	if _, err := tx.Exec(updateQuery, updateArgs...); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	// record the order in the same transaction as the inventory update, so the two can never
+	// diverge.
+	orderID, err := order.CreateTx(tx, userID, lineItems, totalPrice)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	// the cart has now been consumed by the purchase, so clear it.
+	if _, err := tx.Exec("DELETE FROM cart WHERE user_id = $1", userID); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	purchased := make([]PurchaseItem, 0, len(skus))
+	for _, sku := range skus {
+		purchased = append(purchased, PurchaseItem{SKU: sku, Qty: qtyBySKU[sku]})
+	}
+
+	return &PurchaseResponse{
+		UserID:         userID,
+		OrderID:        orderID,
+		ItemsPurchased: purchased,
+		TotalPrice:     totalPrice,
+	}, nil
+}
@@ -0,0 +1,176 @@
+package user
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRequireAuth(t *testing.T) {
+	validToken, err := GenerateToken(7)
+	if err != nil {
+		t.Fatalf("failed to generate token: %s", err)
+	}
+
+	expiredClaims := claims{
+		UserID: 7,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(time.Now().Add(-2 * tokenTTL)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-tokenTTL)),
+		},
+	}
+	expiredToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, expiredClaims).SignedString(jwtSecret())
+	if err != nil {
+		t.Fatalf("failed to sign expired token: %s", err)
+	}
+
+	tests := []struct {
+		name           string
+		authHeader     string
+		expectedStatus int
+		expectReached  bool
+	}{
+		{
+			name:           "missing authorization header",
+			authHeader:     "",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "malformed authorization header",
+			authHeader:     "Token abc123",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "invalid token",
+			authHeader:     "Bearer not-a-real-token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "expired token",
+			authHeader:     "Bearer " + expiredToken,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "valid token",
+			authHeader:     "Bearer " + validToken,
+			expectedStatus: http.StatusOK,
+			expectReached:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			var reachedUserID string
+			router.GET("/protected", RequireAuth(), func(ctx *gin.Context) {
+				reachedUserID, _ = UserIDFromContext(ctx)
+				ctx.Status(http.StatusOK)
+			})
+
+			req, err := http.NewRequest(http.MethodGet, "/protected", nil)
+			if err != nil {
+				t.Fatalf("failed to create HTTP request: %s", err)
+			}
+			if tc.authHeader != "" {
+				req.Header.Set("Authorization", tc.authHeader)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+
+			if tc.expectReached && reachedUserID != "7" {
+				t.Errorf("expected downstream handler to see user id 7, got %q", reachedUserID)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenHandler(t *testing.T) {
+	validToken, err := GenerateToken(7)
+	if err != nil {
+		t.Fatalf("failed to generate token: %s", err)
+	}
+
+	expiredClaims := claims{
+		UserID: 7,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(time.Now().Add(-2 * tokenTTL)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-tokenTTL)),
+		},
+	}
+	expiredToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, expiredClaims).SignedString(jwtSecret())
+	if err != nil {
+		t.Fatalf("failed to sign expired token: %s", err)
+	}
+
+	tests := []struct {
+		name           string
+		authHeader     string
+		expectedStatus int
+	}{
+		{
+			name:           "missing authorization header",
+			authHeader:     "",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "expired token",
+			authHeader:     "Bearer " + expiredToken,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "valid token",
+			authHeader:     "Bearer " + validToken,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+			router.POST("/refresh", RefreshTokenHandler)
+
+			req, err := http.NewRequest(http.MethodPost, "/refresh", nil)
+			if err != nil {
+				t.Fatalf("failed to create HTTP request: %s", err)
+			}
+			if tc.authHeader != "" {
+				req.Header.Set("Authorization", tc.authHeader)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+
+			if tc.expectedStatus == http.StatusOK {
+				var resp LoginResponse
+				if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("failed to decode response: %s", err)
+				}
+
+				c, err := parseToken(resp.Token)
+				if err != nil {
+					t.Fatalf("expected refreshed token to be valid: %s", err)
+				}
+				if c.UserID != 7 {
+					t.Errorf("expected refreshed token to carry user id 7, got %d", c.UserID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package user
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"lugbit/projects/checkout/database"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type User struct {
+	ID           int64     `json:"id"`
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type RegisterRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type RegisterResponse struct {
+	ID    int64  `json:"id"`
+	Email string `json:"email"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type LoginResponse struct {
+	Token string `json:"token"`
+}
+
+// Register creates a new user account, storing a bcrypt hash of the password rather than the
+// password itself. If the email already exists, registration will fail.
+func Register(ctx *gin.Context) {
+	var req RegisterRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid JSON body"})
+		return
+	}
+
+	if req.Email == "" || req.Password == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "email and password required"})
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "could not hash password"})
+		return
+	}
+
+	var id int64
+	row := database.Db.QueryRow(
+		"INSERT INTO users (email, password_hash, created_at) VALUES ($1, $2, now()) RETURNING id",
+		req.Email, string(hash),
+	)
+	if err := row.Scan(&id); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "unable to register user"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, RegisterResponse{ID: id, Email: req.Email})
+}
+
+// Login verifies the given email/password against the stored bcrypt hash and, on success,
+// returns a signed JWT the caller can present on subsequent requests.
+func Login(ctx *gin.Context) {
+	var req LoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid JSON body"})
+		return
+	}
+
+	var id int64
+	var hash string
+	row := database.Db.QueryRow("SELECT id, password_hash FROM users WHERE email = $1", req.Email)
+	if err := row.Scan(&id, &hash); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "could not look up user"})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.Password)); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
+		return
+	}
+
+	token, err := GenerateToken(id)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "could not generate token"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, LoginResponse{Token: token})
+}
+
+// RefreshTokenHandler issues a new access token for the caller's currently valid token, so a
+// client can extend its session without re-sending credentials before the token expires.
+func RefreshTokenHandler(ctx *gin.Context) {
+	header := ctx.GetHeader("Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(header, prefix) {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing or invalid authorization header"})
+		return
+	}
+
+	token, err := RefreshToken(strings.TrimPrefix(header, prefix))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, LoginResponse{Token: token})
+}
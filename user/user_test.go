@@ -0,0 +1,236 @@
+package user
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"lugbit/projects/checkout/database"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		name             string
+		requestBody      RegisterRequest
+		invalidJSON      bool
+		mockSetup        func(mock sqlmock.Sqlmock)
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			name:             "invalid JSON body",
+			invalidJSON:      true,
+			mockSetup:        func(mock sqlmock.Sqlmock) {},
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: `{"error":"invalid JSON body"}`,
+		},
+		{
+			name:             "missing email and password",
+			requestBody:      RegisterRequest{},
+			mockSetup:        func(mock sqlmock.Sqlmock) {},
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: `{"error":"email and password required"}`,
+		},
+		{
+			name: "successful registration",
+			requestBody: RegisterRequest{
+				Email:    "new@example.com",
+				Password: "hunter2",
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id"}).AddRow(42)
+				mock.ExpectQuery("INSERT INTO users \\(email, password_hash, created_at\\) VALUES \\(\\$1, \\$2, now\\(\\)\\) RETURNING id").
+					WithArgs("new@example.com", sqlmock.AnyArg()).
+					WillReturnRows(rows)
+			},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"id":42,"email":"new@example.com"}`,
+		},
+		{
+			name: "email already registered",
+			requestBody: RegisterRequest{
+				Email:    "taken@example.com",
+				Password: "hunter2",
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO users \\(email, password_hash, created_at\\) VALUES \\(\\$1, \\$2, now\\(\\)\\) RETURNING id").
+					WithArgs("taken@example.com", sqlmock.AnyArg()).
+					WillReturnError(sql.ErrConnDone)
+			},
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: `{"error":"unable to register user"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock database: %s", err)
+			}
+			defer db.Close()
+			database.Db = db
+
+			tc.mockSetup(mock)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+			router.POST("/register", Register)
+
+			var reqBody []byte
+			if tc.invalidJSON {
+				reqBody = []byte("invalid-json")
+			} else {
+				reqBody, err = json.Marshal(tc.requestBody)
+				if err != nil {
+					t.Fatalf("error marshalling request body: %s", err)
+				}
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(reqBody))
+			if err != nil {
+				t.Fatalf("failed to create HTTP request: %s", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+
+			if body := rr.Body.String(); body != tc.expectedResponse {
+				t.Errorf("expected response %s, got %s", tc.expectedResponse, body)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	const password = "hunter2"
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		t.Fatalf("failed to hash password: %s", err)
+	}
+
+	tests := []struct {
+		name           string
+		requestBody    LoginRequest
+		mockSetup      func(mock sqlmock.Sqlmock)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "user not found",
+			requestBody: LoginRequest{
+				Email:    "ghost@example.com",
+				Password: password,
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT id, password_hash FROM users WHERE email = \\$1").
+					WithArgs("ghost@example.com").
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "invalid email or password",
+		},
+		{
+			name: "wrong password",
+			requestBody: LoginRequest{
+				Email:    "user@example.com",
+				Password: "wrong-password",
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "password_hash"}).AddRow(7, string(hash))
+				mock.ExpectQuery("SELECT id, password_hash FROM users WHERE email = \\$1").
+					WithArgs("user@example.com").
+					WillReturnRows(rows)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "invalid email or password",
+		},
+		{
+			name: "successful login",
+			requestBody: LoginRequest{
+				Email:    "user@example.com",
+				Password: password,
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "password_hash"}).AddRow(7, string(hash))
+				mock.ExpectQuery("SELECT id, password_hash FROM users WHERE email = \\$1").
+					WithArgs("user@example.com").
+					WillReturnRows(rows)
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock database: %s", err)
+			}
+			defer db.Close()
+			database.Db = db
+
+			tc.mockSetup(mock)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+			router.POST("/login", Login)
+
+			reqBody, err := json.Marshal(tc.requestBody)
+			if err != nil {
+				t.Fatalf("error marshalling request body: %s", err)
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(reqBody))
+			if err != nil {
+				t.Fatalf("failed to create HTTP request: %s", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+
+			if tc.expectedStatus == http.StatusOK {
+				var resp LoginResponse
+				if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("error unmarshalling response: %s", err)
+				}
+				if resp.Token == "" {
+					t.Errorf("expected a non-empty token")
+				}
+			} else {
+				var resp gin.H
+				if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("error unmarshalling response: %s", err)
+				}
+				if resp["error"] != tc.expectedError {
+					t.Errorf("expected error %q, got %q", tc.expectedError, resp["error"])
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}